@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/mattermost/mattermost-server/model"
+
+	"github.com/kippsterr/mattermost-plugin-giphy/oembed"
+)
+
+// gifContentAttachment builds the attachment used to display a single GIF. When rich
+// previews are enabled and the provider exposes a canonical page URL, it is built from
+// Giphy's oEmbed metadata; otherwise it falls back to the plain inline image template.
+//
+// Rich previews are only available for the Giphy provider: Giphy's oEmbed endpoint
+// doesn't recognize other providers' page URLs (e.g. Tenor's), so the template fallback
+// is used for them regardless of UseRichPreview.
+func gifContentAttachment(config *GiphyPluginConfiguration, keywords, gifURL, pageURL string) *model.SlackAttachment {
+	if config.UseRichPreview && pageURL != "" && isGiphyProvider(config.Provider) {
+		if data, err := oembed.GetGiphyOembed(pageURL); err == nil {
+			return &model.SlackAttachment{
+				AuthorName: data.ProviderName,
+				Title:      data.Title,
+				TitleLink:  data.URL,
+				ImageURL:   gifURL,
+			}
+		}
+	}
+
+	return &model.SlackAttachment{
+		Text: applyResponseTemplate(config.ResponseTemplate, keywords, gifURL),
+	}
+}
+
+// isGiphyProvider reports whether provider identifies the Giphy provider, the default
+// when unset.
+func isGiphyProvider(provider string) bool {
+	return provider == "" || provider == providerGiphy
+}
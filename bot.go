@@ -0,0 +1,26 @@
+package main
+
+import "github.com/mattermost/mattermost-server/model"
+
+const botUsername = "giphy"
+
+// ensureBot creates the Giphy bot account used to post GIFs, or reuses it if a
+// plugin upgrade or restart finds it already exists, returning its user id.
+func (p *GiphyPlugin) ensureBot() (string, error) {
+	bot := &model.Bot{
+		Username:    botUsername,
+		DisplayName: "Giphy",
+		Description: "Created by the Giphy plugin.",
+	}
+
+	createdBot, appErr := p.api.CreateBot(bot)
+	if appErr == nil {
+		return createdBot.UserId, nil
+	}
+
+	user, userErr := p.api.GetUserByUsername(botUsername)
+	if userErr != nil {
+		return "", appErr
+	}
+	return user.Id, nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import "strconv"
+
+// cursorKey returns the KV store key used to track how far a user has shuffled
+// through the GIF results for their current search in a given channel.
+func cursorKey(userID, channelID string) string {
+	return "cursor_" + userID + "_" + channelID
+}
+
+// getCursor returns the current search offset for a user within a channel,
+// defaulting to 0 if none has been recorded yet.
+func (p *GiphyPlugin) getCursor(userID, channelID string) (int, error) {
+	data, appErr := p.api.KVGet(cursorKey(userID, channelID))
+	if appErr != nil {
+		return 0, appErr
+	}
+	if data == nil {
+		return 0, nil
+	}
+	return strconv.Atoi(string(data))
+}
+
+// setCursor persists the search offset for a user within a channel.
+func (p *GiphyPlugin) setCursor(userID, channelID string, offset int) error {
+	appErr := p.api.KVSet(cursorKey(userID, channelID), []byte(strconv.Itoa(offset)))
+	if appErr != nil {
+		return appErr
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import "encoding/json"
+
+// favoriteGif is a single bookmarked GIF, persisted per user in the plugin KV store.
+type favoriteGif struct {
+	URL      string `json:"url"`
+	Keywords string `json:"keywords"`
+	Provider string `json:"provider"`
+}
+
+func favoritesKey(userID string) string {
+	return "favorites_" + userID
+}
+
+func historyKey(userID string) string {
+	return "history_" + userID
+}
+
+// lastShownGif is the GIF most recently previewed, shuffled to, or sent by a user in a
+// channel. It's recorded verbatim so /gif favorite can bookmark exactly what the user
+// saw without re-running the search (which could return a different GIF).
+type lastShownGif struct {
+	Keywords string `json:"keywords"`
+	URL      string `json:"url"`
+	PageURL  string `json:"pageUrl"`
+}
+
+func lastShownGifKey(userID, channelID string) string {
+	return "last_gif_" + userID + "_" + channelID
+}
+
+// getLastShownGif returns the last GIF shown to a user in a channel, or nil if none.
+func (p *GiphyPlugin) getLastShownGif(userID, channelID string) (*lastShownGif, error) {
+	data, appErr := p.api.KVGet(lastShownGifKey(userID, channelID))
+	if appErr != nil {
+		return nil, appErr
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	var gif lastShownGif
+	if err := json.Unmarshal(data, &gif); err != nil {
+		return nil, err
+	}
+	return &gif, nil
+}
+
+// setLastShownGif records the GIF just shown to a user in a channel.
+func (p *GiphyPlugin) setLastShownGif(userID, channelID string, gif lastShownGif) error {
+	data, err := json.Marshal(gif)
+	if err != nil {
+		return err
+	}
+	if appErr := p.api.KVSet(lastShownGifKey(userID, channelID), data); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// getFavorites returns the GIFs a user has bookmarked, oldest first.
+func (p *GiphyPlugin) getFavorites(userID string) ([]favoriteGif, error) {
+	data, appErr := p.api.KVGet(favoritesKey(userID))
+	if appErr != nil {
+		return nil, appErr
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	var favorites []favoriteGif
+	if err := json.Unmarshal(data, &favorites); err != nil {
+		return nil, err
+	}
+	return favorites, nil
+}
+
+func (p *GiphyPlugin) setFavorites(userID string, favorites []favoriteGif) error {
+	data, err := json.Marshal(favorites)
+	if err != nil {
+		return err
+	}
+	if appErr := p.api.KVSet(favoritesKey(userID), data); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// addFavorite bookmarks a GIF for a user, ignoring duplicates and dropping the oldest
+// favorite once maxFavorites is reached. maxFavorites <= 0 means no cap.
+func (p *GiphyPlugin) addFavorite(userID string, favorite favoriteGif, maxFavorites int) error {
+	favorites, err := p.getFavorites(userID)
+	if err != nil {
+		return err
+	}
+
+	return p.setFavorites(userID, withFavoriteAdded(favorites, favorite, maxFavorites))
+}
+
+// withFavoriteAdded returns favorites with favorite appended, unless its URL is already
+// present, dropping the oldest entries once maxFavorites is reached. maxFavorites <= 0
+// means no cap.
+func withFavoriteAdded(favorites []favoriteGif, favorite favoriteGif, maxFavorites int) []favoriteGif {
+	for _, existing := range favorites {
+		if existing.URL == favorite.URL {
+			return favorites
+		}
+	}
+
+	favorites = append(favorites, favorite)
+	if maxFavorites > 0 && len(favorites) > maxFavorites {
+		favorites = favorites[len(favorites)-maxFavorites:]
+	}
+	return favorites
+}
+
+// removeFavorite unbookmarks the GIF at url for a user, if present.
+func (p *GiphyPlugin) removeFavorite(userID, url string) error {
+	favorites, err := p.getFavorites(userID)
+	if err != nil {
+		return err
+	}
+
+	return p.setFavorites(userID, withFavoriteRemoved(favorites, url))
+}
+
+// withFavoriteRemoved returns favorites with the entry at url removed, if present.
+func withFavoriteRemoved(favorites []favoriteGif, url string) []favoriteGif {
+	filtered := make([]favoriteGif, 0, len(favorites))
+	for _, existing := range favorites {
+		if existing.URL != url {
+			filtered = append(filtered, existing)
+		}
+	}
+	return filtered
+}
+
+// getLastSearch returns the keywords of a user's most recent /gif search, or "" if
+// they have never searched.
+func (p *GiphyPlugin) getLastSearch(userID string) (string, error) {
+	data, appErr := p.api.KVGet(historyKey(userID))
+	if appErr != nil {
+		return "", appErr
+	}
+	return string(data), nil
+}
+
+// setLastSearch records the keywords of a user's most recent /gif search, so a bare
+// /gif with no keywords can replay it.
+func (p *GiphyPlugin) setLastSearch(userID, keywords string) error {
+	if appErr := p.api.KVSet(historyKey(userID), []byte(keywords)); appErr != nil {
+		return appErr
+	}
+	return nil
+}
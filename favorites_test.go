@@ -0,0 +1,105 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithFavoriteAdded(t *testing.T) {
+	cats := favoriteGif{URL: "cats.gif", Keywords: "cats"}
+	dogs := favoriteGif{URL: "dogs.gif", Keywords: "dogs"}
+	birds := favoriteGif{URL: "birds.gif", Keywords: "birds"}
+
+	tests := []struct {
+		name         string
+		favorites    []favoriteGif
+		favorite     favoriteGif
+		maxFavorites int
+		want         []favoriteGif
+	}{
+		{
+			name:         "appends to an empty list",
+			favorites:    nil,
+			favorite:     cats,
+			maxFavorites: 0,
+			want:         []favoriteGif{cats},
+		},
+		{
+			name:         "appends to an existing list",
+			favorites:    []favoriteGif{cats},
+			favorite:     dogs,
+			maxFavorites: 0,
+			want:         []favoriteGif{cats, dogs},
+		},
+		{
+			name:         "ignores a duplicate URL",
+			favorites:    []favoriteGif{cats, dogs},
+			favorite:     favoriteGif{URL: cats.URL, Keywords: "kittens"},
+			maxFavorites: 0,
+			want:         []favoriteGif{cats, dogs},
+		},
+		{
+			name:         "drops the oldest entry once over the cap",
+			favorites:    []favoriteGif{cats, dogs},
+			favorite:     birds,
+			maxFavorites: 2,
+			want:         []favoriteGif{dogs, birds},
+		},
+		{
+			name:         "does not cap when maxFavorites is 0",
+			favorites:    []favoriteGif{cats, dogs},
+			favorite:     birds,
+			maxFavorites: 0,
+			want:         []favoriteGif{cats, dogs, birds},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := withFavoriteAdded(tt.favorites, tt.favorite, tt.maxFavorites)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withFavoriteAdded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithFavoriteRemoved(t *testing.T) {
+	cats := favoriteGif{URL: "cats.gif", Keywords: "cats"}
+	dogs := favoriteGif{URL: "dogs.gif", Keywords: "dogs"}
+
+	tests := []struct {
+		name      string
+		favorites []favoriteGif
+		url       string
+		want      []favoriteGif
+	}{
+		{
+			name:      "removes a matching entry",
+			favorites: []favoriteGif{cats, dogs},
+			url:       cats.URL,
+			want:      []favoriteGif{dogs},
+		},
+		{
+			name:      "leaves the list unchanged when the url isn't present",
+			favorites: []favoriteGif{cats, dogs},
+			url:       "missing.gif",
+			want:      []favoriteGif{cats, dogs},
+		},
+		{
+			name:      "returns an empty list when the last entry is removed",
+			favorites: []favoriteGif{cats},
+			url:       cats.URL,
+			want:      []favoriteGif{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := withFavoriteRemoved(tt.favorites, tt.url)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withFavoriteRemoved() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import "fmt"
+
+const (
+	providerGiphy = "giphy"
+	providerTenor = "tenor"
+	providerGuggy = "guggy"
+)
+
+// gifResult is a single GIF returned by a gifProvider: the URL to display, and its
+// canonical page URL, which is "" if the provider has no such page. pageURL is used
+// to build a rich oEmbed preview.
+type gifResult struct {
+	URL     string
+	PageURL string
+}
+
+// gifProvider is a source of GIFs that can be searched by keywords.
+type gifProvider interface {
+	// getGif returns a single GIF matching the given keywords. offset selects which
+	// match to return, so repeated calls with an incremented offset can be used to
+	// shuffle through the results of the same search.
+	getGif(config *GiphyPluginConfiguration, request string, offset int) (gifResult, error)
+	// getMultipleGifs returns a list of GIFs matching the given keywords
+	getMultipleGifs(config *GiphyPluginConfiguration, request string) ([]gifResult, error)
+}
+
+// newProviderRegistry builds the set of gifProvider implementations known to the plugin,
+// keyed by their GiphyPluginConfiguration.Provider identifier.
+func newProviderRegistry() map[string]gifProvider {
+	return map[string]gifProvider{
+		providerGiphy: &giphyProvider{},
+		providerTenor: &tenorProvider{},
+		providerGuggy: &guggyProvider{},
+	}
+}
+
+// selectProvider returns the gifProvider configured for use, along with a non-nil error
+// if it's missing an API key it requires. The provider is still returned alongside that
+// error so callers can keep using it (and have it fail per-request) rather than treat a
+// save-time misconfiguration as fatal; only an unknown Provider name returns no provider
+// at all, since no registry entry exists to fall back to.
+func selectProvider(registry map[string]gifProvider, config *GiphyPluginConfiguration) (gifProvider, error) {
+	providerName := config.Provider
+	if providerName == "" {
+		providerName = providerGiphy
+	}
+
+	provider, ok := registry[providerName]
+	if !ok {
+		return nil, fmt.Errorf("unknown GIF provider '%s'", providerName)
+	}
+
+	switch providerName {
+	case providerGiphy:
+		if config.APIKey == "" {
+			return provider, fmt.Errorf("a Giphy API Key is required to use the Giphy provider")
+		}
+	case providerTenor:
+		if config.TenorAPIKey == "" {
+			return provider, fmt.Errorf("a Tenor API Key is required to use the Tenor provider")
+		}
+	}
+
+	return provider, nil
+}
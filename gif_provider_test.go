@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+func TestSelectProvider(t *testing.T) {
+	registry := newProviderRegistry()
+
+	tests := []struct {
+		name      string
+		config    *GiphyPluginConfiguration
+		wantNil   bool
+		wantError bool
+	}{
+		{
+			name:      "defaults to giphy when Provider is unset",
+			config:    &GiphyPluginConfiguration{APIKey: "key"},
+			wantNil:   false,
+			wantError: false,
+		},
+		{
+			name:      "giphy without an API key errors but still returns the provider",
+			config:    &GiphyPluginConfiguration{Provider: providerGiphy},
+			wantNil:   false,
+			wantError: true,
+		},
+		{
+			name:      "tenor without an API key errors but still returns the provider",
+			config:    &GiphyPluginConfiguration{Provider: providerTenor},
+			wantNil:   false,
+			wantError: true,
+		},
+		{
+			name:      "tenor with an API key succeeds",
+			config:    &GiphyPluginConfiguration{Provider: providerTenor, TenorAPIKey: "key"},
+			wantNil:   false,
+			wantError: false,
+		},
+		{
+			name:      "guggy requires no API key",
+			config:    &GiphyPluginConfiguration{Provider: providerGuggy},
+			wantNil:   false,
+			wantError: false,
+		},
+		{
+			name:      "an unknown provider name returns no provider at all",
+			config:    &GiphyPluginConfiguration{Provider: "unknown"},
+			wantNil:   true,
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := selectProvider(registry, tt.config)
+			if (provider == nil) != tt.wantNil {
+				t.Errorf("selectProvider() provider = %v, wantNil %v", provider, tt.wantNil)
+			}
+			if (err != nil) != tt.wantError {
+				t.Errorf("selectProvider() error = %v, wantError %v", err, tt.wantError)
+			}
+		})
+	}
+}
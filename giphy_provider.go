@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+const giphyAPIURL = "https://api.giphy.com/v1/gifs/search"
+
+// giphyProvider is a gifProvider that searches GIFs using the Giphy API.
+type giphyProvider struct {
+}
+
+type giphyImage struct {
+	URL string `json:"url"`
+}
+
+type giphyImages struct {
+	Original   giphyImage `json:"original"`
+	Downsized  giphyImage `json:"downsized"`
+	Fixed      giphyImage `json:"fixed_height"`
+	FixedSmall giphyImage `json:"fixed_height_small"`
+}
+
+type giphyGif struct {
+	URL    string      `json:"url"`
+	Images giphyImages `json:"images"`
+}
+
+type giphySearchResponse struct {
+	Data []giphyGif `json:"data"`
+}
+
+func (p *giphyProvider) getGif(config *GiphyPluginConfiguration, request string, offset int) (gifResult, error) {
+	result, err := p.search(config, request, 1, offset)
+	if err != nil {
+		return gifResult{}, err
+	}
+	if len(result) == 0 {
+		return gifResult{}, fmt.Errorf("no matching GIFs found")
+	}
+	gif := result[0]
+	return gifResult{URL: giphyRendition(gif.Images, config.Rendition).URL, PageURL: gif.URL}, nil
+}
+
+func (p *giphyProvider) getMultipleGifs(config *GiphyPluginConfiguration, request string) ([]gifResult, error) {
+	result, err := p.search(config, request, 10, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	gifs := make([]gifResult, 0, len(result))
+	for _, gif := range result {
+		gifs = append(gifs, gifResult{URL: giphyRendition(gif.Images, config.Rendition).URL, PageURL: gif.URL})
+	}
+	return gifs, nil
+}
+
+func (p *giphyProvider) search(config *GiphyPluginConfiguration, request string, limit int, offset int) ([]giphyGif, error) {
+	query := url.Values{}
+	query.Set("api_key", config.APIKey)
+	query.Set("q", request)
+	query.Set("limit", fmt.Sprintf("%d", limit))
+	query.Set("offset", fmt.Sprintf("%d", offset))
+	query.Set("rating", config.Rating)
+	query.Set("lang", config.Language)
+
+	response, err := http.Get(giphyAPIURL + "?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var searchResponse giphySearchResponse
+	if err := json.Unmarshal(body, &searchResponse); err != nil {
+		return nil, err
+	}
+
+	return searchResponse.Data, nil
+}
+
+func giphyRendition(images giphyImages, rendition string) giphyImage {
+	switch rendition {
+	case "downsized":
+		return images.Downsized
+	case "fixed_height":
+		return images.Fixed
+	case "fixed_height_small":
+		return images.FixedSmall
+	default:
+		return images.Original
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestGiphyRendition(t *testing.T) {
+	images := giphyImages{
+		Original:   giphyImage{URL: "original.gif"},
+		Downsized:  giphyImage{URL: "downsized.gif"},
+		Fixed:      giphyImage{URL: "fixed.gif"},
+		FixedSmall: giphyImage{URL: "fixed_small.gif"},
+	}
+
+	tests := []struct {
+		rendition string
+		want      string
+	}{
+		{rendition: "downsized", want: images.Downsized.URL},
+		{rendition: "fixed_height", want: images.Fixed.URL},
+		{rendition: "fixed_height_small", want: images.FixedSmall.URL},
+		{rendition: "original", want: images.Original.URL},
+		{rendition: "", want: images.Original.URL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rendition, func(t *testing.T) {
+			got := giphyRendition(images, tt.rendition)
+			if got.URL != tt.want {
+				t.Errorf("giphyRendition(%q) = %q, want %q", tt.rendition, got.URL, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+const guggyAPIURL = "https://text2gif.guggy.com/guggify"
+
+// guggyProvider is a gifProvider that turns keywords into an animated GIF
+// generated from text using the Guggy API.
+type guggyProvider struct {
+}
+
+type guggyRequest struct {
+	Format   string `json:"format"`
+	Sentence string `json:"sentence"`
+}
+
+type guggyResponse struct {
+	GIF string `json:"gif"`
+}
+
+// getGif's PageURL is always "": Guggy's generated GIFs have no canonical page to
+// build a rich preview from.
+func (p *guggyProvider) getGif(config *GiphyPluginConfiguration, request string, offset int) (gifResult, error) {
+	gifURL, err := p.generate(request)
+	if err != nil {
+		return gifResult{}, err
+	}
+	return gifResult{URL: gifURL}, nil
+}
+
+func (p *guggyProvider) getMultipleGifs(config *GiphyPluginConfiguration, request string) ([]gifResult, error) {
+	gifURL, err := p.generate(request)
+	if err != nil {
+		return nil, err
+	}
+	return []gifResult{{URL: gifURL}}, nil
+}
+
+func (p *guggyProvider) generate(sentence string) (string, error) {
+	payload, err := json.Marshal(guggyRequest{Format: "gif", Sentence: sentence})
+	if err != nil {
+		return "", err
+	}
+
+	response, err := http.Post(guggyAPIURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var guggyResp guggyResponse
+	if err := json.Unmarshal(body, &guggyResp); err != nil {
+		return "", err
+	}
+	if guggyResp.GIF == "" {
+		return "", fmt.Errorf("no matching GIF found")
+	}
+
+	return guggyResp.GIF, nil
+}
@@ -0,0 +1,48 @@
+// Package oembed fetches GIF metadata from Giphy's oEmbed endpoint so it can be
+// rendered as a rich link preview instead of an inline image.
+package oembed
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+const giphyOembedURL = "https://giphy.com/services/oembed"
+
+// GiphyOembedData is the subset of Giphy's oEmbed response needed to build a preview.
+type GiphyOembedData struct {
+	ProviderName string `json:"provider_name"`
+	Title        string `json:"title"`
+	URL          string `json:"url"`
+}
+
+// GetGiphyOembed fetches the oEmbed metadata for the GIF page at gifPageURL.
+func GetGiphyOembed(gifPageURL string) (*GiphyOembedData, error) {
+	query := url.Values{}
+	query.Set("url", gifPageURL)
+
+	response, err := http.Get(giphyOembedURL + "?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("giphy oembed request failed with status %d", response.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var data GiphyOembedData
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync/atomic"
 
@@ -15,6 +18,24 @@ const (
 	// Triggers used to define slash commands
 	triggerGif  = "gif"
 	triggerGifs = "gifs"
+
+	// pluginID must match the id in plugin.json, and is used to build absolute
+	// URLs to this plugin's HTTP handlers
+	pluginID = "giphy"
+
+	routeShuffle      = "/shuffle"
+	routeSend         = "/send"
+	routeCancel       = "/cancel"
+	routeFavorite     = "/favorite"
+	routeProfileImage = "/profile.png"
+
+	contextKeywords = "keywords"
+	contextGifURL   = "gifURL"
+
+	// Subcommands of /gif that manage favorites instead of searching for a GIF
+	subcommandFavorite   = "favorite"
+	subcommandFavorites  = "favorites"
+	subcommandUnfavorite = "unfavorite"
 )
 
 // GiphyPlugin is a Mattermost plugin that adds a /gif slash command
@@ -22,17 +43,23 @@ const (
 type GiphyPlugin struct {
 	api           plugin.API
 	configuration atomic.Value
+	providers     map[string]gifProvider
 	gifProvider   gifProvider
+	botUserID     string
 	enabled       bool
 }
 
 // GiphyPluginConfiguration contains Mattermost GiphyPlugin configuration settings
 type GiphyPluginConfiguration struct {
-	Rating           string
-	Language         string
-	Rendition        string
-	ResponseTemplate string
-	APIKey           string
+	Provider            string
+	Rating              string
+	Language            string
+	Rendition           string
+	ResponseTemplate    string
+	APIKey              string
+	TenorAPIKey         string
+	UseRichPreview      bool
+	MaxFavoritesPerUser int
 }
 
 // OnActivate register the plugin commands
@@ -63,6 +90,12 @@ func (p *GiphyPlugin) OnActivate(api plugin.API) error {
 		return err
 	}
 
+	botUserID, err := p.ensureBot()
+	if err != nil {
+		return err
+	}
+	p.botUserID = botUserID
+
 	return p.OnConfigurationChange()
 }
 
@@ -74,8 +107,19 @@ func (p *GiphyPlugin) config() *GiphyPluginConfiguration {
 func (p *GiphyPlugin) OnConfigurationChange() error {
 	var configuration GiphyPluginConfiguration
 	err := p.api.LoadPluginConfiguration(&configuration)
+	if err != nil {
+		return err
+	}
 	p.configuration.Store(&configuration)
-	return err
+
+	// A provider misconfiguration (e.g. a missing API key) shouldn't abort activation
+	// or leave p.gifProvider pointing at a provider the configuration no longer selects;
+	// it should only surface once /gif is actually run against it.
+	if provider, _ := selectProvider(p.providers, &configuration); provider != nil {
+		p.gifProvider = provider
+	}
+
+	return nil
 }
 
 // OnDeactivate handles plugin deactivation
@@ -93,44 +137,383 @@ func (p *GiphyPlugin) ExecuteCommand(args *model.CommandArgs) (*model.CommandRes
 		return nil, appError("Cannot access the plugin API.", nil)
 	}
 	if strings.HasPrefix(args.Command, "/"+triggerGifs) {
-		return p.executeCommandGifs(args.Command)
+		return p.executeCommandGifs(args)
 	}
 	if strings.HasPrefix(args.Command, "/"+triggerGif) {
-		return p.executeCommandGif(args.Command)
+		return p.executeCommandGif(args)
 	}
 
 	return nil, appError("Command trigger "+args.Command+"is not supported by this plugin.", nil)
 }
 
-// executeCommandGif returns a public post containing a matching GIF
-func (p *GiphyPlugin) executeCommandGif(command string) (*model.CommandResponse, *model.AppError) {
-	keywords := getCommandKeywords(command, triggerGif)
+// executeCommandGif returns an ephemeral post with a preview of the first matching GIF
+// and buttons to send it to the channel, shuffle to another match, or cancel. It also
+// routes the favorite/unfavorite/favorites subcommands and, given no keywords, replays
+// the user's last search.
+func (p *GiphyPlugin) executeCommandGif(args *model.CommandArgs) (*model.CommandResponse, *model.AppError) {
+	rest := getCommandKeywords(args.Command, triggerGif)
+
+	words := strings.Fields(rest)
+	switch {
+	case rest == subcommandFavorites:
+		return p.executeCommandFavorites(args)
+	case rest == subcommandFavorite:
+		return p.executeCommandFavorite(args)
+	case len(words) > 0 && words[0] == subcommandUnfavorite:
+		return p.executeCommandUnfavorite(args, strings.TrimSpace(strings.TrimPrefix(rest, subcommandUnfavorite)))
+	}
+
+	keywords := rest
+	if keywords == "" {
+		lastSearch, err := p.getLastSearch(args.UserId)
+		if err != nil {
+			return nil, appError("Unable to load search history", err)
+		}
+		keywords = lastSearch
+	}
+	if keywords == "" {
+		return nil, appError("Please provide keywords, e.g. /gif happy kitty", nil)
+	}
+
 	config := p.config()
-	gifURL, err := p.gifProvider.getGifURL(config, keywords)
+
+	offset := 0
+	gif, err := p.gifProvider.getGif(config, keywords, offset)
 	if err != nil {
 		return nil, appError("Unable to get GIF URL", err)
 	}
+	if err := p.setCursor(args.UserId, args.ChannelId, offset); err != nil {
+		return nil, appError("Unable to store GIF cursor", err)
+	}
+	if err := p.setLastSearch(args.UserId, keywords); err != nil {
+		return nil, appError("Unable to store search history", err)
+	}
+	if err := p.setLastShownGif(args.UserId, args.ChannelId, lastShownGif{Keywords: keywords, URL: gif.URL, PageURL: gif.PageURL}); err != nil {
+		return nil, appError("Unable to store last shown GIF", err)
+	}
 
-	text := applyResponseTemplate(config.ResponseTemplate, keywords, gifURL)
-	return &model.CommandResponse{ResponseType: model.COMMAND_RESPONSE_TYPE_IN_CHANNEL, Text: text}, nil
+	return &model.CommandResponse{
+		ResponseType: model.COMMAND_RESPONSE_TYPE_EPHEMERAL,
+		Attachments:  []*model.SlackAttachment{p.previewAttachment(config, keywords, gif.URL, gif.PageURL)},
+	}, nil
 }
 
-// executeCommandGifs returns a private post containing a list of matching GIFs
-func (p *GiphyPlugin) executeCommandGifs(command string) (*model.CommandResponse, *model.AppError) {
-	keywords := getCommandKeywords(command, triggerGifs)
-	gifURLs, err := p.gifProvider.getMultipleGifsURL(p.config(), keywords)
+// executeCommandFavorite bookmarks the GIF the user was just shown in this channel
+func (p *GiphyPlugin) executeCommandFavorite(args *model.CommandArgs) (*model.CommandResponse, *model.AppError) {
+	gif, err := p.getLastShownGif(args.UserId, args.ChannelId)
+	if err != nil {
+		return nil, appError("Unable to load last shown GIF", err)
+	}
+	if gif == nil {
+		return nil, appError("Search for a GIF with /gif before favoriting it.", nil)
+	}
+
+	config := p.config()
+	favorite := favoriteGif{URL: gif.URL, Keywords: gif.Keywords, Provider: config.Provider}
+	if err := p.addFavorite(args.UserId, favorite, config.MaxFavoritesPerUser); err != nil {
+		return nil, appError("Unable to save favorite", err)
+	}
+
+	return &model.CommandResponse{ResponseType: model.COMMAND_RESPONSE_TYPE_EPHEMERAL, Text: "Added to your favorites."}, nil
+}
+
+// executeCommandFavorites lists the GIFs a user has bookmarked
+func (p *GiphyPlugin) executeCommandFavorites(args *model.CommandArgs) (*model.CommandResponse, *model.AppError) {
+	favorites, err := p.getFavorites(args.UserId)
+	if err != nil {
+		return nil, appError("Unable to load favorites", err)
+	}
+	if len(favorites) == 0 {
+		return &model.CommandResponse{ResponseType: model.COMMAND_RESPONSE_TYPE_EPHEMERAL, Text: "You have no favorite GIFs yet."}, nil
+	}
+
+	attachments := make([]*model.SlackAttachment, 0, len(favorites))
+	for i, favorite := range favorites {
+		attachments = append(attachments, &model.SlackAttachment{
+			Text:     fmt.Sprintf("%d. '%s'", i+1, favorite.Keywords),
+			ImageURL: favorite.URL,
+		})
+	}
+
+	return &model.CommandResponse{
+		ResponseType: model.COMMAND_RESPONSE_TYPE_EPHEMERAL,
+		Text:         "Use `/gif unfavorite <number>` to remove one.",
+		Attachments:  attachments,
+	}, nil
+}
+
+// executeCommandUnfavorite removes the favorite at the given 1-based index
+func (p *GiphyPlugin) executeCommandUnfavorite(args *model.CommandArgs, indexArg string) (*model.CommandResponse, *model.AppError) {
+	favorites, err := p.getFavorites(args.UserId)
+	if err != nil {
+		return nil, appError("Unable to load favorites", err)
+	}
+
+	index, convErr := parseFavoriteIndex(indexArg, len(favorites))
+	if convErr != nil {
+		return nil, appError(convErr.Error(), nil)
+	}
+
+	if err := p.removeFavorite(args.UserId, favorites[index-1].URL); err != nil {
+		return nil, appError("Unable to remove favorite", err)
+	}
+
+	return &model.CommandResponse{ResponseType: model.COMMAND_RESPONSE_TYPE_EPHEMERAL, Text: "Removed from your favorites."}, nil
+}
+
+// parseFavoriteIndex validates indexArg as a 1-based index into a favorites list of the
+// given count, returning the usage or range error to show the user otherwise.
+func parseFavoriteIndex(indexArg string, count int) (int, error) {
+	index, convErr := strconv.Atoi(indexArg)
+	if convErr != nil || index < 1 {
+		return 0, fmt.Errorf("Usage: /gif unfavorite <number>, see /gif favorites for the list.")
+	}
+	if index > count {
+		return 0, fmt.Errorf("No favorite with that number.")
+	}
+	return index, nil
+}
+
+// previewAttachment builds the Send/Shuffle/Cancel attachment shown while previewing a GIF
+func (p *GiphyPlugin) previewAttachment(config *GiphyPluginConfiguration, keywords, gifURL, pageURL string) *model.SlackAttachment {
+	attachment := gifContentAttachment(config, keywords, gifURL, pageURL)
+	context := map[string]interface{}{contextKeywords: keywords}
+
+	attachment.Actions = []*model.PostAction{
+		{
+			Id:   "send",
+			Name: "Send",
+			Type: model.POST_ACTION_TYPE_BUTTON,
+			Integration: &model.PostActionIntegration{
+				URL:     p.siteURL() + "/plugins/" + pluginID + routeSend,
+				Context: context,
+			},
+		},
+		{
+			Id:   "shuffle",
+			Name: "Shuffle",
+			Type: model.POST_ACTION_TYPE_BUTTON,
+			Integration: &model.PostActionIntegration{
+				URL:     p.siteURL() + "/plugins/" + pluginID + routeShuffle,
+				Context: context,
+			},
+		},
+		{
+			Id:   "cancel",
+			Name: "Cancel",
+			Type: model.POST_ACTION_TYPE_BUTTON,
+			Integration: &model.PostActionIntegration{
+				URL:     p.siteURL() + "/plugins/" + pluginID + routeCancel,
+				Context: context,
+			},
+		},
+	}
+
+	return attachment
+}
+
+// siteURL returns the configured Mattermost site URL, used to build absolute
+// callback URLs for this plugin's interactive message buttons.
+func (p *GiphyPlugin) siteURL() string {
+	config := p.api.GetConfig()
+	if config == nil || config.ServiceSettings.SiteURL == nil {
+		return ""
+	}
+	return *config.ServiceSettings.SiteURL
+}
+
+// ServeHTTP handles the HTTP callbacks fired by the Send/Shuffle/Cancel buttons
+func (p *GiphyPlugin) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case routeShuffle:
+		p.handleShuffle(w, r)
+	case routeSend:
+		p.handleSend(w, r)
+	case routeCancel:
+		p.handleCancel(w, r)
+	case routeFavorite:
+		p.handleFavorite(w, r)
+	case routeProfileImage:
+		p.handleProfileImage(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleProfileImage serves the bot's avatar from the plugin bundle, so it can be
+// referenced by an absolute URL in a post's override_icon_url Prop.
+func (p *GiphyPlugin) handleProfileImage(w http.ResponseWriter, r *http.Request) {
+	bundlePath, err := p.api.GetBundlePath()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	img, err := ioutil.ReadFile(filepath.Join(bundlePath, "assets", "profile.png"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(img)
+}
+
+// profileImageURL returns the absolute URL the bot's avatar is served at.
+func (p *GiphyPlugin) profileImageURL() string {
+	return p.siteURL() + "/plugins/" + pluginID + routeProfileImage
+}
+
+func (p *GiphyPlugin) handleShuffle(w http.ResponseWriter, r *http.Request) {
+	request := model.PostActionIntegrationRequestFromJson(r.Body)
+	if request == nil {
+		http.Error(w, "invalid action request", http.StatusBadRequest)
+		return
+	}
+	keywords, _ := request.Context[contextKeywords].(string)
+
+	offset, err := p.getCursor(request.UserId, request.ChannelId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	offset++
+
+	config := p.config()
+	gif, err := p.gifProvider.getGif(config, keywords, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := p.setCursor(request.UserId, request.ChannelId, offset); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := p.setLastShownGif(request.UserId, request.ChannelId, lastShownGif{Keywords: keywords, URL: gif.URL, PageURL: gif.PageURL}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := &model.PostActionIntegrationResponse{
+		Update: &model.Post{
+			Props: map[string]interface{}{
+				"attachments": []*model.SlackAttachment{p.previewAttachment(config, keywords, gif.URL, gif.PageURL)},
+			},
+		},
+	}
+	w.Write([]byte(response.ToJson()))
+}
+
+func (p *GiphyPlugin) handleSend(w http.ResponseWriter, r *http.Request) {
+	request := model.PostActionIntegrationRequestFromJson(r.Body)
+	if request == nil {
+		http.Error(w, "invalid action request", http.StatusBadRequest)
+		return
+	}
+	keywords, _ := request.Context[contextKeywords].(string)
+
+	offset, err := p.getCursor(request.UserId, request.ChannelId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	config := p.config()
+	gif, err := p.gifProvider.getGif(config, keywords, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	post := &model.Post{
+		UserId:    p.botUserID,
+		ChannelId: request.ChannelId,
+		Props: map[string]interface{}{
+			"attachments":       []*model.SlackAttachment{gifContentAttachment(config, keywords, gif.URL, gif.PageURL)},
+			"override_username": "Giphy",
+			"override_icon_url": p.profileImageURL(),
+		},
+	}
+	if _, appErr := p.api.CreatePost(post); appErr != nil {
+		http.Error(w, appErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := &model.PostActionIntegrationResponse{
+		Update: &model.Post{Message: "", Props: map[string]interface{}{}},
+	}
+	w.Write([]byte(response.ToJson()))
+}
+
+// handleFavorite bookmarks the GIF behind a /gifs result's ★ button
+func (p *GiphyPlugin) handleFavorite(w http.ResponseWriter, r *http.Request) {
+	request := model.PostActionIntegrationRequestFromJson(r.Body)
+	if request == nil {
+		http.Error(w, "invalid action request", http.StatusBadRequest)
+		return
+	}
+	keywords, _ := request.Context[contextKeywords].(string)
+	gifURL, _ := request.Context[contextGifURL].(string)
+
+	config := p.config()
+	favorite := favoriteGif{URL: gifURL, Keywords: keywords, Provider: config.Provider}
+	if err := p.addFavorite(request.UserId, favorite, config.MaxFavoritesPerUser); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := &model.PostActionIntegrationResponse{EphemeralText: "Added to your favorites."}
+	w.Write([]byte(response.ToJson()))
+}
+
+func (p *GiphyPlugin) handleCancel(w http.ResponseWriter, r *http.Request) {
+	response := &model.PostActionIntegrationResponse{
+		Update: &model.Post{Message: "Cancelled.", Props: map[string]interface{}{}},
+	}
+	w.Write([]byte(response.ToJson()))
+}
+
+// executeCommandGifs returns a private post containing a list of matching GIFs, each
+// with a star button to bookmark it as a favorite.
+func (p *GiphyPlugin) executeCommandGifs(args *model.CommandArgs) (*model.CommandResponse, *model.AppError) {
+	keywords := getCommandKeywords(args.Command, triggerGifs)
+	config := p.config()
+
+	gifs, err := p.gifProvider.getMultipleGifs(config, keywords)
 	if err != nil {
 		return nil, appError("Unable to get GIF URL", err)
 	}
 
-	text := fmt.Sprintf(" *Suggestions for '%s':*", keywords)
-	for i, url := range gifURLs {
-		if i > 0 {
-			text += "\t"
-		}
-		text += fmt.Sprintf("[![GIF for '%s'](%s)](%s)", keywords, url, url)
+	attachments := make([]*model.SlackAttachment, 0, len(gifs))
+	for _, gif := range gifs {
+		attachments = append(attachments, p.gifsPreviewAttachment(config, keywords, gif))
 	}
-	return &model.CommandResponse{ResponseType: model.COMMAND_RESPONSE_TYPE_EPHEMERAL, Text: text}, nil
+
+	return &model.CommandResponse{
+		ResponseType: model.COMMAND_RESPONSE_TYPE_EPHEMERAL,
+		Text:         fmt.Sprintf("*Suggestions for '%s':*", keywords),
+		Attachments:  attachments,
+	}, nil
+}
+
+// gifsPreviewAttachment builds a single /gifs result, with a ★ button to favorite it
+func (p *GiphyPlugin) gifsPreviewAttachment(config *GiphyPluginConfiguration, keywords string, gif gifResult) *model.SlackAttachment {
+	attachment := gifContentAttachment(config, keywords, gif.URL, gif.PageURL)
+
+	context := map[string]interface{}{contextKeywords: keywords, contextGifURL: gif.URL}
+	attachment.Actions = []*model.PostAction{
+		{
+			Id:   "favorite",
+			Name: "★",
+			Type: model.POST_ACTION_TYPE_BUTTON,
+			Integration: &model.PostActionIntegration{
+				URL:     p.siteURL() + "/plugins/" + pluginID + routeFavorite,
+				Context: context,
+			},
+		},
+	}
+
+	return attachment
 }
 
 func getCommandKeywords(commandLine string, trigger string) string {
@@ -155,6 +538,6 @@ func appError(message string, err error) *model.AppError {
 // Install the RCP plugin
 func main() {
 	plugin := GiphyPlugin{}
-	plugin.gifProvider = &giphyProvider{}
+	plugin.providers = newProviderRegistry()
 	rpcplugin.Main(&plugin)
 }
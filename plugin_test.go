@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestParseFavoriteIndex(t *testing.T) {
+	tests := []struct {
+		name      string
+		indexArg  string
+		count     int
+		want      int
+		wantError bool
+	}{
+		{name: "first favorite", indexArg: "1", count: 3, want: 1},
+		{name: "last favorite", indexArg: "3", count: 3, want: 3},
+		{name: "zero is out of range", indexArg: "0", count: 3, wantError: true},
+		{name: "negative is out of range", indexArg: "-1", count: 3, wantError: true},
+		{name: "past the end of the list", indexArg: "4", count: 3, wantError: true},
+		{name: "not a number", indexArg: "abc", count: 3, wantError: true},
+		{name: "empty list", indexArg: "1", count: 0, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFavoriteIndex(tt.indexArg, tt.count)
+			if tt.wantError {
+				if err == nil {
+					t.Fatalf("parseFavoriteIndex(%q, %d) = %d, nil; want an error", tt.indexArg, tt.count, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFavoriteIndex(%q, %d) returned unexpected error: %v", tt.indexArg, tt.count, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseFavoriteIndex(%q, %d) = %d, want %d", tt.indexArg, tt.count, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+const tenorAPIURL = "https://g.tenor.com/v1/search"
+
+// tenorProvider is a gifProvider that searches GIFs using the Tenor API.
+type tenorProvider struct {
+}
+
+type tenorMediaRendition struct {
+	URL string `json:"url"`
+}
+
+type tenorMedia struct {
+	Gif       tenorMediaRendition `json:"gif"`
+	TinyGif   tenorMediaRendition `json:"tinygif"`
+	MediumGif tenorMediaRendition `json:"mediumgif"`
+}
+
+type tenorResult struct {
+	ItemURL string       `json:"itemurl"`
+	Media   []tenorMedia `json:"media"`
+}
+
+type tenorSearchResponse struct {
+	Results []tenorResult `json:"results"`
+}
+
+func (p *tenorProvider) getGif(config *GiphyPluginConfiguration, request string, offset int) (gifResult, error) {
+	result, err := p.search(config, request, 1, offset)
+	if err != nil {
+		return gifResult{}, err
+	}
+	if len(result) == 0 || len(result[0].Media) == 0 {
+		return gifResult{}, fmt.Errorf("no matching GIFs found")
+	}
+	item := result[0]
+	return gifResult{URL: tenorRendition(item.Media[0], config.Rendition), PageURL: item.ItemURL}, nil
+}
+
+func (p *tenorProvider) getMultipleGifs(config *GiphyPluginConfiguration, request string) ([]gifResult, error) {
+	result, err := p.search(config, request, 10, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	gifs := make([]gifResult, 0, len(result))
+	for _, item := range result {
+		if len(item.Media) == 0 {
+			continue
+		}
+		gifs = append(gifs, gifResult{URL: tenorRendition(item.Media[0], config.Rendition), PageURL: item.ItemURL})
+	}
+	return gifs, nil
+}
+
+func (p *tenorProvider) search(config *GiphyPluginConfiguration, request string, limit int, offset int) ([]tenorResult, error) {
+	query := url.Values{}
+	query.Set("key", config.TenorAPIKey)
+	query.Set("q", request)
+	query.Set("media_filter", "minimal")
+	query.Set("limit", fmt.Sprintf("%d", limit))
+	query.Set("pos", fmt.Sprintf("%d", offset))
+	query.Set("contentfilter", tenorContentFilter(config.Rating))
+	query.Set("locale", config.Language)
+
+	response, err := http.Get(tenorAPIURL + "?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var searchResponse tenorSearchResponse
+	if err := json.Unmarshal(body, &searchResponse); err != nil {
+		return nil, err
+	}
+
+	return searchResponse.Results, nil
+}
+
+// tenorContentFilter maps the Giphy-style Rating setting onto Tenor's contentfilter values.
+func tenorContentFilter(rating string) string {
+	switch rating {
+	case "y", "g":
+		return "high"
+	case "pg":
+		return "medium"
+	case "pg-13":
+		return "low"
+	default:
+		return "off"
+	}
+}
+
+func tenorRendition(media tenorMedia, rendition string) string {
+	switch rendition {
+	case "tinygif", "fixed_height_small":
+		return media.TinyGif.URL
+	case "mediumgif":
+		return media.MediumGif.URL
+	default:
+		return media.Gif.URL
+	}
+}
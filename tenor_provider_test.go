@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestTenorRendition(t *testing.T) {
+	media := tenorMedia{
+		Gif:       tenorMediaRendition{URL: "gif.gif"},
+		TinyGif:   tenorMediaRendition{URL: "tinygif.gif"},
+		MediumGif: tenorMediaRendition{URL: "mediumgif.gif"},
+	}
+
+	tests := []struct {
+		rendition string
+		want      string
+	}{
+		{rendition: "tinygif", want: media.TinyGif.URL},
+		{rendition: "fixed_height_small", want: media.TinyGif.URL},
+		{rendition: "mediumgif", want: media.MediumGif.URL},
+		{rendition: "gif", want: media.Gif.URL},
+		{rendition: "", want: media.Gif.URL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rendition, func(t *testing.T) {
+			got := tenorRendition(media, tt.rendition)
+			if got != tt.want {
+				t.Errorf("tenorRendition(%q) = %q, want %q", tt.rendition, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTenorContentFilter(t *testing.T) {
+	tests := []struct {
+		rating string
+		want   string
+	}{
+		{rating: "y", want: "high"},
+		{rating: "g", want: "high"},
+		{rating: "pg", want: "medium"},
+		{rating: "pg-13", want: "low"},
+		{rating: "r", want: "off"},
+		{rating: "", want: "off"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rating, func(t *testing.T) {
+			got := tenorContentFilter(tt.rating)
+			if got != tt.want {
+				t.Errorf("tenorContentFilter(%q) = %q, want %q", tt.rating, got, tt.want)
+			}
+		})
+	}
+}